@@ -0,0 +1,49 @@
+// Command hcprotod runs hcproto as a standalone HTTP/gRPC service.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/vchimishuk/hcproto"
+	"github.com/vchimishuk/hcproto/server"
+)
+
+func main() {
+	httpAddr := flag.String("http", ":8080", "REST API listen address, empty to disable")
+	grpcAddr := flag.String("grpc", ":9090", "gRPC listen address, empty to disable")
+	rateLimit := flag.Float64("rate-limit", 0, "per-client requests per second, 0 to disable")
+	fetchTimeout := flag.Duration("fetch-timeout", 5*time.Second, "per-URL title fetch timeout")
+	flag.Parse()
+
+	// Cache outermost so a hit skips retries and timeouts; timeout
+	// innermost so it bounds a single attempt, not the whole retry
+	// sequence.
+	hg := server.InstrumentedGetter(hcproto.Chain(
+		http.DefaultClient,
+		hcproto.WithInMemoryCache(time.Minute, 1000),
+		hcproto.WithRetry(2, 200*time.Millisecond),
+		hcproto.WithTimeout(*fetchTimeout),
+	))
+	parser := hcproto.NewParserWithOptions(hg,
+		hcproto.WithFetchTimeout(*fetchTimeout),
+	)
+
+	srv := server.New(parser, server.Options{
+		HTTPAddr:  *httpAddr,
+		GRPCAddr:  *grpcAddr,
+		RateLimit: *rateLimit,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := srv.ListenAndServe(ctx); err != nil {
+		log.Fatalf(`{"msg":"hcprotod exited","error":%q}`, err.Error())
+	}
+}
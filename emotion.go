@@ -0,0 +1,198 @@
+package hcproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EmotionCatalog reports whether an emotion name (without the
+// surrounding parentheses) is supported. Implementations must be safe
+// for concurrent use, since a Parser may be used from several
+// goroutines at once, each of them calling Contains.
+type EmotionCatalog interface {
+	Contains(name string) bool
+}
+
+// StaticEmotionCatalog is the default EmotionCatalog. It's backed by
+// the fixed, built-in supportedEmotions list and requires no setup.
+type StaticEmotionCatalog struct{}
+
+// Contains implements EmotionCatalog.
+func (StaticEmotionCatalog) Contains(name string) bool {
+	i := sort.SearchStrings(supportedEmotions, name)
+	return i < len(supportedEmotions) && supportedEmotions[i] == name
+}
+
+// sliceEmotionCatalog is an EmotionCatalog over a sorted slice of
+// names that can be swapped out at runtime, e.g. on refresh.
+type sliceEmotionCatalog struct {
+	mu    sync.RWMutex
+	names []string
+}
+
+func newSliceEmotionCatalog(names []string) *sliceEmotionCatalog {
+	c := &sliceEmotionCatalog{}
+	c.set(names)
+
+	return c
+}
+
+func (c *sliceEmotionCatalog) set(names []string) {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	c.mu.Lock()
+	c.names = sorted
+	c.mu.Unlock()
+}
+
+// Contains implements EmotionCatalog.
+func (c *sliceEmotionCatalog) Contains(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i := sort.SearchStrings(c.names, name)
+	return i < len(c.names) && c.names[i] == name
+}
+
+// NewFileEmotionCatalog reads a list of emotion names from a local
+// JSON or YAML file, chosen by the path's extension (".yaml"/".yml"
+// for YAML, anything else for JSON). The file's content is a plain
+// array of strings, e.g. ["shipit", "lgtm"].
+func NewFileEmotionCatalog(path string) (EmotionCatalog, error) {
+	names, err := readEmotionFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSliceEmotionCatalog(names), nil
+}
+
+func readEmotionFile(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &names)
+	default:
+		err = json.Unmarshal(data, &names)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("hcproto: parse emotion catalog %s: %w", path, err)
+	}
+
+	return names, nil
+}
+
+// HTTPEmotionCatalog is an EmotionCatalog fetched from a JSON endpoint
+// returning an array of emotion names, e.g. ["shipit", "lgtm"]. It can
+// optionally refresh itself in the background; call Close to stop.
+type HTTPEmotionCatalog struct {
+	*sliceEmotionCatalog
+	url    string
+	client *http.Client
+	stop   chan struct{}
+}
+
+// HTTPEmotionCatalogOption configures NewHTTPEmotionCatalog.
+type HTTPEmotionCatalogOption func(*HTTPEmotionCatalog)
+
+// RefreshInterval makes the catalog periodically re-fetch names from
+// its URL in the background. Zero, the default, fetches once and never
+// refreshes. Fetch errors during refresh are ignored, leaving the
+// previously loaded names in place.
+func RefreshInterval(d time.Duration) HTTPEmotionCatalogOption {
+	return func(c *HTTPEmotionCatalog) {
+		if d <= 0 {
+			return
+		}
+
+		go func() {
+			t := time.NewTicker(d)
+			defer t.Stop()
+
+			for {
+				select {
+				case <-t.C:
+					if names, err := c.fetch(); err == nil {
+						c.set(names)
+					}
+				case <-c.stop:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// WithHTTPClient overrides the http.Client used to fetch the catalog.
+// The default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) HTTPEmotionCatalogOption {
+	return func(c *HTTPEmotionCatalog) {
+		c.client = client
+	}
+}
+
+// NewHTTPEmotionCatalog fetches the initial emotion list from url and
+// returns a catalog backed by it, optionally kept fresh by
+// RefreshInterval.
+func NewHTTPEmotionCatalog(url string, opts ...HTTPEmotionCatalogOption) (*HTTPEmotionCatalog, error) {
+	c := &HTTPEmotionCatalog{
+		sliceEmotionCatalog: newSliceEmotionCatalog(nil),
+		url:                 url,
+		client:              http.DefaultClient,
+		stop:                make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	names, err := c.fetch()
+	if err != nil {
+		close(c.stop)
+		return nil, err
+	}
+	c.set(names)
+
+	return c, nil
+}
+
+func (c *HTTPEmotionCatalog) fetch() ([]string, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("hcproto: fetch emotion catalog %s: status %s", c.url, resp.Status)
+	}
+
+	var names []string
+	if err := json.NewDecoder(resp.Body).Decode(&names); err != nil {
+		return nil, fmt.Errorf("hcproto: decode emotion catalog %s: %w", c.url, err)
+	}
+
+	return names, nil
+}
+
+// Close stops the background refresh started by RefreshInterval, if
+// any. It's a no-op otherwise.
+func (c *HTTPEmotionCatalog) Close() error {
+	close(c.stop)
+	return nil
+}
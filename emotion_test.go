@@ -0,0 +1,153 @@
+package hcproto
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewFileEmotionCatalogJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "emotions.json")
+	data, err := json.Marshal([]string{"lgtm", "shipit"})
+	if err != nil {
+		t.Fatalf("marshal fixture: %s", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write fixture: %s", err)
+	}
+
+	catalog, err := NewFileEmotionCatalog(path)
+	if err != nil {
+		t.Fatalf("NewFileEmotionCatalog failed: %s", err)
+	}
+
+	if !catalog.Contains("lgtm") || !catalog.Contains("shipit") {
+		t.Fatalf("catalog missing expected names: %#v", catalog)
+	}
+	if catalog.Contains("atlassian") {
+		t.Fatalf("catalog should not contain names outside the file")
+	}
+}
+
+func TestNewFileEmotionCatalogYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "emotions.yaml")
+	data := []byte("- lgtm\n- shipit\n")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write fixture: %s", err)
+	}
+
+	catalog, err := NewFileEmotionCatalog(path)
+	if err != nil {
+		t.Fatalf("NewFileEmotionCatalog failed: %s", err)
+	}
+
+	if !catalog.Contains("lgtm") || !catalog.Contains("shipit") {
+		t.Fatalf("catalog missing expected names: %#v", catalog)
+	}
+}
+
+func TestNewFileEmotionCatalogMissingFile(t *testing.T) {
+	_, err := NewFileEmotionCatalog(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
+
+func TestNewHTTPEmotionCatalog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]string{"lgtm", "shipit"})
+	}))
+	defer srv.Close()
+
+	catalog, err := NewHTTPEmotionCatalog(srv.URL)
+	if err != nil {
+		t.Fatalf("NewHTTPEmotionCatalog failed: %s", err)
+	}
+	defer catalog.Close()
+
+	if !catalog.Contains("lgtm") || !catalog.Contains("shipit") {
+		t.Fatalf("catalog missing expected names: %#v", catalog)
+	}
+}
+
+func TestNewHTTPEmotionCatalogError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTPEmotionCatalog(srv.URL)
+	if err == nil {
+		t.Fatalf("expected error for non-200 response")
+	}
+}
+
+// namesFixture is a slice of emotion names an httptest handler can
+// serve while a separate goroutine swaps its contents, e.g. to
+// simulate an upstream catalog changing between refreshes.
+type namesFixture struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (f *namesFixture) set(names []string) {
+	f.mu.Lock()
+	f.names = names
+	f.mu.Unlock()
+}
+
+func (f *namesFixture) get() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.names
+}
+
+func TestHTTPEmotionCatalogRefresh(t *testing.T) {
+	fixture := &namesFixture{names: []string{"lgtm"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(fixture.get())
+	}))
+	defer srv.Close()
+
+	catalog, err := NewHTTPEmotionCatalog(srv.URL, RefreshInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewHTTPEmotionCatalog failed: %s", err)
+	}
+	defer catalog.Close()
+
+	if catalog.Contains("shipit") {
+		t.Fatalf("catalog should not contain shipit before refresh")
+	}
+
+	fixture.set([]string{"lgtm", "shipit"})
+
+	deadline := time.Now().Add(time.Second)
+	for !catalog.Contains("shipit") {
+		if time.Now().After(deadline) {
+			t.Fatalf("catalog did not pick up refreshed names in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHTTPEmotionCatalogClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]string{"lgtm"})
+	}))
+	defer srv.Close()
+
+	catalog, err := NewHTTPEmotionCatalog(srv.URL, RefreshInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewHTTPEmotionCatalog failed: %s", err)
+	}
+
+	if err := catalog.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+}
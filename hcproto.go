@@ -1,17 +1,16 @@
 package hcproto
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"sort"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 	"unicode/utf8"
-
-	"golang.org/x/net/html"
 )
 
 // Supported emotions list.
@@ -49,47 +48,113 @@ type MsgInfo struct {
 	Emotions []string `json:"emotions,omitempty"`
 	// Links is a list of mentioned links.
 	Links []Link `json:"links,omitempty"`
+	// MentionHits, EmotionHits and LinkHits carry the byte offsets of
+	// each element within the source message. They are only populated
+	// when the Parser was built with WithPositions(), so the JSON
+	// shape above is unaffected by default.
+	MentionHits []MentionHit `json:"mention_hits,omitempty"`
+	EmotionHits []EmotionHit `json:"emotion_hits,omitempty"`
+	LinkHits    []LinkHit    `json:"link_hits,omitempty"`
 }
 
 // Parser is a HipChat messages parser implementation.
 type Parser struct {
-	hg HTTPGetter
+	hg             HTTPGetter
+	catalog        EmotionCatalog
+	maxConcurrency int
+	fetchTimeout   time.Duration
+	maxTitleBytes  int64
+	positions      bool
+	streamWindow   int
 }
 
 // NewParser returns newly created Parser which uses given HTTPGetter
 // for HTML pages quering during links parsing. It has no state so can
 // be used in concurrent environment. http.Client can be used as HTTPGetter.
 func NewParser(hg HTTPGetter) *Parser {
-	return &Parser{hg: hg}
+	return NewParserWithOptions(hg)
 }
 
 // Parse parses a message and returns information about elements
 // (emotions, mentions, links) which it contains.
 func (p *Parser) Parse(msg string) (*MsgInfo, error) {
-	var mentions []string
-	var emotions []string
-	var urls []string
+	return p.ParseContext(context.Background(), msg)
+}
+
+// ParseContext is like Parse but threads ctx into every outbound title
+// fetch, so in-flight fetches are abandoned once ctx is done. Links are
+// returned in the order the URLs appear in msg, regardless of which
+// fetch finishes first.
+func (p *Parser) ParseContext(ctx context.Context, msg string) (*MsgInfo, error) {
+	sr, err := p.scan(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.buildMsgInfo(ctx, sr), nil
+}
+
+// scanResult holds what a scan of a message (or stream) found, plus
+// per-element spans used when the Parser was built with WithPositions().
+type scanResult struct {
+	mentions    []string
+	emotions    []string
+	urls        []string
+	mentionHits []MentionHit
+	emotionHits []EmotionHit
+	urlSpans    []Span
+}
+
+// buildMsgInfo resolves sr.urls into Links and assembles the final
+// MsgInfo, attaching spans when p.positions is set.
+func (p *Parser) buildMsgInfo(ctx context.Context, sr scanResult) *MsgInfo {
+	links := p.urlsToLinks(ctx, sr.urls)
+
+	mi := &MsgInfo{
+		Mentions: sr.mentions,
+		Emotions: sr.emotions,
+		Links:    links,
+	}
+
+	if p.positions {
+		mi.MentionHits = sr.mentionHits
+		mi.EmotionHits = sr.emotionHits
+		mi.LinkHits = make([]LinkHit, len(links))
+		for i, l := range links {
+			mi.LinkHits[i] = LinkHit{Link: l, Span: sr.urlSpans[i]}
+		}
+	}
+
+	return mi
+}
+
+// scan walks msg once and collects mentions, emotions and URLs in the
+// order they occur, along with their byte spans.
+func (p *Parser) scan(msg string) (scanResult, error) {
+	var sr scanResult
 	var i int
 
 	for i < len(msg) {
 		s := msg[i:]
 		r, size := utf8.DecodeRuneInString(s)
 		if r == utf8.RuneError {
-			return nil, fmt.Errorf("invalid rune at position %d", i)
+			return scanResult{}, fmt.Errorf("invalid rune at position %d", i)
 		}
 
 		switch r {
 		case '(':
-			e, n := emotion(s)
+			e, n := emotion(s, p.catalog)
 			if e != "" {
 				size = n
-				emotions = append(emotions, e)
+				sr.emotions = append(sr.emotions, e)
+				sr.emotionHits = append(sr.emotionHits, EmotionHit{Name: e, Span: Span{Start: i, End: i + n}})
 			}
 		case '@':
 			m, n := mention(s)
 			if m != "" {
 				size = n
-				mentions = append(mentions, m)
+				sr.mentions = append(sr.mentions, m)
+				sr.mentionHits = append(sr.mentionHits, MentionHit{Name: m, Span: Span{Start: i, End: i + n}})
 			}
 		case 'H', 'h':
 			// Check that link doesn't start at the middle
@@ -102,7 +167,8 @@ func (p *Parser) Parse(msg string) (*MsgInfo, error) {
 			if start {
 				url, n := link(s)
 				if url != "" {
-					urls = append(urls, url)
+					sr.urls = append(sr.urls, url)
+					sr.urlSpans = append(sr.urlSpans, Span{Start: i, End: i + n})
 					size = n
 				}
 			}
@@ -111,11 +177,7 @@ func (p *Parser) Parse(msg string) (*MsgInfo, error) {
 		i += size
 	}
 
-	return &MsgInfo{
-		Mentions: mentions,
-		Emotions: emotions,
-		Links:    p.urlsToLinks(urls),
-	}, nil
+	return sr, nil
 }
 
 func (p *Parser) ParseJSON(msg string) (string, error) {
@@ -131,87 +193,72 @@ func (p *Parser) ParseJSON(msg string) (string, error) {
 	return string(s), nil
 }
 
-// TODO: urlToLinks returns Links in random order: page downloaded first
-//       generates first Link. It is better to keep the original order.
-func (p *Parser) urlsToLinks(urls []string) []Link {
-	switch len(urls) {
-	case 0:
+// urlsToLinks resolves the titles for urls concurrently, bounded by
+// p.maxConcurrency, and returns Links in the same order as urls
+// regardless of which fetch finishes first. It stops starting new
+// fetches once ctx is done; fetches already in flight are abandoned.
+func (p *Parser) urlsToLinks(ctx context.Context, urls []string) []Link {
+	if len(urls) == 0 {
 		return nil
-	case 1:
-		// Ignore title errors. Log it in realworld app.
-		t, _ := p.pagetTitle(urls[0])
-		return []Link{{URL: urls[0], Title: t}}
-	default:
-		ch := make(chan Link, len(urls))
-		for _, url := range urls {
-			go func(u string) {
-				// Ignore title errors. Log it in realworld app.
-				t, _ := p.pagetTitle(u)
-				ch <- Link{URL: u, Title: t}
-			}(url)
-		}
+	}
 
-		links := make([]Link, 0, len(urls))
-		for i := 0; i < len(urls); i++ {
-			links = append(links, <-ch)
+	links := make([]Link, len(urls))
+	sem := make(chan struct{}, p.maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, u := range urls {
+		select {
+		case sem <- struct{}{}:
+			if ctx.Err() != nil {
+				<-sem
+				links[i] = Link{URL: u}
+				continue
+			}
+		case <-ctx.Done():
+			links[i] = Link{URL: u}
+			continue
 		}
 
-		return links
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fetchCtx := ctx
+			if p.fetchTimeout > 0 {
+				var cancel context.CancelFunc
+				fetchCtx, cancel = context.WithTimeout(ctx, p.fetchTimeout)
+				defer cancel()
+			}
+
+			// Ignore title errors. Log it in realworld app.
+			t, _ := p.pagetTitle(fetchCtx, u)
+			links[i] = Link{URL: u, Title: t}
+		}(i, u)
 	}
+	wg.Wait()
+
+	return links
 }
 
-func (p *Parser) pagetTitle(url string) (string, error) {
-	resp, err := p.hg.Get(url)
+func (p *Parser) pagetTitle(ctx context.Context, url string) (string, error) {
+	resp, err := getContext(ctx, p.hg, url)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	t := html.NewTokenizer(resp.Body)
-	head := false
-	for {
-		tt := t.Next()
-
-		switch tt {
-		case html.ErrorToken:
-			return "", errors.New("invalid html")
-		case html.StartTagToken:
-			name, _ := t.TagName()
-
-			switch string(name) {
-			case "head":
-				head = true
-			case "title":
-				if true || head {
-					if t.Next() == html.TextToken {
-						return string(t.Text()), nil
-					}
-				}
-			case "body":
-				// Page has no TITLE tag.
-				return "", nil
-			}
-		case html.EndTagToken:
-			name, _ := t.TagName()
-
-			if string(name) == "head" {
-				return "", nil
-			}
-		}
-	}
-
-	return "", errors.New("invalid html")
+	return newTitleExtractor(p.maxTitleBytes).extract(resp)
 }
 
-func emotion(s string) (string, int) {
+func emotion(s string, catalog EmotionCatalog) (string, int) {
 	i := strings.Index(s, ")")
 	if i == -1 {
 		return "", 0
 	}
 	e := s[1:i]
 
-	i = sort.SearchStrings(supportedEmotions, e)
-	if !(i < len(supportedEmotions) && supportedEmotions[i] == e) {
+	if !catalog.Contains(e) {
 		return "", 0
 	}
 
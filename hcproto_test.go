@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 const golangOrgPage = `
@@ -128,7 +129,7 @@ func TestParse(t *testing.T) {
 		var miJsonOrig MsgInfo
 		err = json.Unmarshal([]byte(miJson), &miJsonOrig)
 		if err != nil {
-			t.Fatalf("Testcase %d JSON unmarshaling failed: %s", err)
+			t.Fatalf("Testcase %d JSON unmarshaling failed: %s", i, err)
 		}
 
 		if !msgInfoEqual(tc.MsgInfo, mi) {
@@ -167,3 +168,51 @@ func linksEqual(a, b []Link) bool {
 
 	return true
 }
+
+// slowHTTPGetter serves a fixed body for each URL after an artificial
+// delay, so fetches can be made to complete out of order.
+type slowHTTPGetter struct {
+	bodies map[string]string
+	delays map[string]time.Duration
+}
+
+func (g slowHTTPGetter) Get(url string) (*http.Response, error) {
+	time.Sleep(g.delays[url])
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(g.bodies[url])),
+	}, nil
+}
+
+// TestParseContextPreservesLinkOrder checks that Links come back in the
+// order the URLs appear in the message, even when the slowest fetch is
+// for the first URL and finishes last.
+func TestParseContextPreservesLinkOrder(t *testing.T) {
+	msg := "http://golang.org first, https://atlassian.com second"
+	hg := slowHTTPGetter{
+		bodies: map[string]string{
+			"http://golang.org":     golangOrgPage,
+			"https://atlassian.com": atlassianComPage,
+		},
+		delays: map[string]time.Duration{
+			"http://golang.org":     20 * time.Millisecond,
+			"https://atlassian.com": 0,
+		},
+	}
+	parser := NewParser(hg)
+
+	mi, err := parser.Parse(msg)
+	if err != nil {
+		t.Fatalf("parsing failed: %s", err)
+	}
+
+	want := []Link{
+		{URL: "http://golang.org", Title: "The Go Programming Language"},
+		{URL: "https://atlassian.com", Title: "Atlassian"},
+	}
+	if !reflect.DeepEqual(mi.Links, want) {
+		t.Fatalf("links out of order: expected %#v found %#v", want, mi.Links)
+	}
+}
@@ -0,0 +1,388 @@
+package hcproto
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// GetterMiddleware wraps an HTTPGetter with additional behavior, e.g.
+// retries or caching. Middlewares compose via Chain.
+type GetterMiddleware func(HTTPGetter) HTTPGetter
+
+// Chain applies mws to base in order, so the first middleware is the
+// outermost one seen by callers. pagetTitle benefits from any
+// middleware applied to the HTTPGetter passed to NewParser.
+//
+// Order matters: put WithInMemoryCache outermost so a hit skips retries
+// and timeouts entirely, and put WithTimeout innermost (closest to
+// base) so it bounds a single attempt rather than a whole WithRetry
+// sequence, e.g. Chain(base, WithInMemoryCache(...), WithRetry(...),
+// WithTimeout(...)).
+func Chain(base HTTPGetter, mws ...GetterMiddleware) HTTPGetter {
+	g := base
+	for i := len(mws) - 1; i >= 0; i-- {
+		g = mws[i](g)
+	}
+
+	return g
+}
+
+// getterFunc adapts a function to HTTPGetter.
+type getterFunc func(url string) (*http.Response, error)
+
+func (f getterFunc) Get(url string) (*http.Response, error) {
+	return f(url)
+}
+
+// getterContextFunc adapts a pair of functions to an HTTPGetter that
+// also implements ContextHTTPGetter.
+type getterContextFunc struct {
+	get        func(url string) (*http.Response, error)
+	getContext func(ctx context.Context, url string) (*http.Response, error)
+}
+
+func (f getterContextFunc) Get(url string) (*http.Response, error) {
+	return f.get(url)
+}
+
+func (f getterContextFunc) GetContext(ctx context.Context, url string) (*http.Response, error) {
+	return f.getContext(ctx, url)
+}
+
+// WithRetry retries a failing Get up to n times, sleeping backoff
+// between attempts. n additional attempts are made on top of the
+// first, so n=2 means up to 3 total calls. It preserves next's
+// ContextHTTPGetter capability, if any, threading the same ctx through
+// every attempt so a caller's cancellation or deadline aborts the
+// whole retry sequence rather than just the attempt in flight.
+func WithRetry(n int, backoff time.Duration) GetterMiddleware {
+	return func(next HTTPGetter) HTTPGetter {
+		r := &retryGetter{next: next, n: n, backoff: backoff}
+		return getterContextFunc{get: r.Get, getContext: r.GetContext}
+	}
+}
+
+type retryGetter struct {
+	next    HTTPGetter
+	n       int
+	backoff time.Duration
+}
+
+func (r *retryGetter) Get(u string) (*http.Response, error) {
+	return r.GetContext(context.Background(), u)
+}
+
+func (r *retryGetter) GetContext(ctx context.Context, u string) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+
+	for i := 0; i <= r.n; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(r.backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		resp, err = getContext(ctx, r.next, u)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return nil, err
+}
+
+// WithTimeout bounds how long a single Get is allowed to take. It's
+// implemented as a timeoutTransport so it also applies when next is
+// (or wraps) an *http.Client.
+func WithTimeout(d time.Duration) GetterMiddleware {
+	return func(next HTTPGetter) HTTPGetter {
+		if c, ok := next.(*http.Client); ok {
+			cc := *c
+			cc.Transport = &timeoutTransport{
+				next:    transportOf(c),
+				timeout: d,
+			}
+			return &cc
+		}
+
+		return getterFunc(func(u string) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), d)
+			defer cancel()
+
+			return getContext(ctx, next, u)
+		})
+	}
+}
+
+// timeoutTransport enforces a per-request deadline on top of an
+// existing http.RoundTripper.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	req = req.WithContext(ctx)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func transportOf(c *http.Client) http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return http.DefaultTransport
+}
+
+// cacheEntry is a single cached response body.
+type cacheEntry struct {
+	body    []byte
+	header  http.Header
+	status  int
+	expires time.Time
+}
+
+// WithInMemoryCache caches responses by URL for ttl, whatever their
+// status code (a Get that returns an error is not cached), evicting the oldest entry once maxEntries is exceeded. It avoids
+// re-fetching the same page every time it's mentioned in a message.
+func WithInMemoryCache(ttl time.Duration, maxEntries int) GetterMiddleware {
+	return func(next HTTPGetter) HTTPGetter {
+		c := &inMemoryCache{
+			next:       next,
+			ttl:        ttl,
+			maxEntries: maxEntries,
+			entries:    make(map[string]cacheEntry),
+		}
+
+		return getterContextFunc{get: c.Get, getContext: c.GetContext}
+	}
+}
+
+type inMemoryCache struct {
+	next       HTTPGetter
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	order   []string
+}
+
+func (c *inMemoryCache) Get(u string) (*http.Response, error) {
+	return c.GetContext(context.Background(), u)
+}
+
+func (c *inMemoryCache) GetContext(ctx context.Context, u string) (*http.Response, error) {
+	if resp, ok := c.load(u); ok {
+		return resp, nil
+	}
+
+	resp, err := getContext(ctx, c.next, u)
+	if err != nil {
+		return nil, err
+	}
+	c.store(u, resp)
+
+	cached, _ := c.load(u)
+	return cached, nil
+}
+
+func (c *inMemoryCache) load(u string) (*http.Response, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[u]
+	c.mu.Unlock()
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+
+	return &http.Response{
+		StatusCode: e.status,
+		Status:     http.StatusText(e.status),
+		Header:     e.header.Clone(),
+		Body:       io.NopCloser(bytes.NewReader(e.body)),
+	}, true
+}
+
+func (c *inMemoryCache) store(u string, resp *http.Response) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[u]; !exists {
+		c.order = append(c.order, u)
+		if c.maxEntries > 0 && len(c.order) > c.maxEntries {
+			oldest := c.order[0]
+			c.order = c.order[1:]
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[u] = cacheEntry{
+		body:    body,
+		header:  resp.Header.Clone(),
+		status:  resp.StatusCode,
+		expires: time.Now().Add(c.ttl),
+	}
+}
+
+// WithHostRateLimit throttles Get calls per host to rps requests per
+// second, so parsing the same message repeatedly doesn't hammer a
+// single site.
+func WithHostRateLimit(rps float64) GetterMiddleware {
+	return func(next HTTPGetter) HTTPGetter {
+		l := &hostRateLimiter{
+			next:     next,
+			rps:      rps,
+			limiters: make(map[string]*rate.Limiter),
+		}
+
+		return getterContextFunc{get: l.Get, getContext: l.GetContext}
+	}
+}
+
+type hostRateLimiter struct {
+	next HTTPGetter
+	rps  float64
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (l *hostRateLimiter) Get(u string) (*http.Response, error) {
+	return l.GetContext(context.Background(), u)
+}
+
+func (l *hostRateLimiter) GetContext(ctx context.Context, u string) (*http.Response, error) {
+	if err := l.limiterFor(u).Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return getContext(ctx, l.next, u)
+}
+
+func (l *hostRateLimiter) limiterFor(u string) *rate.Limiter {
+	host := u
+	if parsed, err := url.Parse(u); err == nil {
+		host = parsed.Host
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r, ok := l.limiters[host]
+	if !ok {
+		r = rate.NewLimiter(rate.Limit(l.rps), 1)
+		l.limiters[host] = r
+	}
+
+	return r
+}
+
+// WithProxy routes requests through the given proxy URL. It only
+// applies when the wrapped getter is an *http.Client.
+func WithProxy(proxyURL string) GetterMiddleware {
+	return func(next HTTPGetter) HTTPGetter {
+		c, ok := next.(*http.Client)
+		if !ok {
+			return next
+		}
+
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return next
+		}
+
+		cc := *c
+		cc.Transport = withProxy(transportOf(c), u)
+
+		return &cc
+	}
+}
+
+// withProxy sets proxy as the dialing proxy on the *http.Transport at
+// the bottom of rt, cloning its way down through this package's own
+// RoundTripper wrappers (timeoutTransport, userAgentTransport) so any
+// of them already applied above rt are preserved rather than discarded.
+func withProxy(rt http.RoundTripper, proxy *url.URL) http.RoundTripper {
+	switch t := rt.(type) {
+	case *http.Transport:
+		cc := t.Clone()
+		cc.Proxy = http.ProxyURL(proxy)
+		return cc
+	case *timeoutTransport:
+		tt := *t
+		tt.next = withProxy(t.next, proxy)
+		return &tt
+	case *userAgentTransport:
+		ut := *t
+		ut.next = withProxy(t.next, proxy)
+		return &ut
+	default:
+		return &http.Transport{Proxy: http.ProxyURL(proxy)}
+	}
+}
+
+// WithUserAgent sets the User-Agent header on every request. It only
+// applies when the wrapped getter is an *http.Client, since plain
+// HTTPGetter has no request to add headers to.
+func WithUserAgent(ua string) GetterMiddleware {
+	return func(next HTTPGetter) HTTPGetter {
+		c, ok := next.(*http.Client)
+		if !ok {
+			return next
+		}
+
+		cc := *c
+		cc.Transport = &userAgentTransport{next: transportOf(c), ua: ua}
+
+		return &cc
+	}
+}
+
+type userAgentTransport struct {
+	next http.RoundTripper
+	ua   string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.ua)
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,299 @@
+package hcproto
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingGetter fails the first failures calls to Get/GetContext, then
+// succeeds, recording how many attempts were made.
+type countingGetter struct {
+	failures int32
+	attempts int32
+}
+
+func (g *countingGetter) Get(u string) (*http.Response, error) {
+	return g.GetContext(context.Background(), u)
+}
+
+func (g *countingGetter) GetContext(ctx context.Context, u string) (*http.Response, error) {
+	n := atomic.AddInt32(&g.attempts, 1)
+	if n <= atomic.LoadInt32(&g.failures) {
+		return nil, errors.New("temporary failure")
+	}
+
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("ok"))}, nil
+}
+
+func TestWithRetrySucceedsAfterFailures(t *testing.T) {
+	g := &countingGetter{failures: 2}
+	hg := WithRetry(3, time.Millisecond)(g)
+
+	resp, err := hg.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	resp.Body.Close()
+	if g.attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", g.attempts)
+	}
+}
+
+func TestWithRetryExhausted(t *testing.T) {
+	g := &countingGetter{failures: 10}
+	hg := WithRetry(2, time.Millisecond)(g)
+
+	_, err := hg.Get("http://example.com")
+	if err == nil {
+		t.Fatalf("expected error after exhausting retries")
+	}
+	if g.attempts != 3 {
+		t.Fatalf("expected 3 attempts (1 + 2 retries), got %d", g.attempts)
+	}
+}
+
+// blockingGetter blocks GetContext until ctx is done, so tests can
+// verify that a cancelled or expired ctx aborts a call in flight.
+type blockingGetter struct{}
+
+func (blockingGetter) Get(u string) (*http.Response, error) {
+	return nil, errors.New("always fails")
+}
+
+func (blockingGetter) GetContext(ctx context.Context, u string) (*http.Response, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWithRetryRespectsContextCancellation(t *testing.T) {
+	hg := WithRetry(5, time.Hour)(blockingGetter{})
+	cg := hg.(ContextHTTPGetter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := cg.GetContext(ctx, "http://example.com")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("GetContext took %s, should have been aborted by ctx", elapsed)
+	}
+}
+
+func TestWithTimeoutHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	hg := WithTimeout(5 * time.Millisecond)(http.DefaultClient)
+
+	_, err := hg.Get(srv.URL)
+	if err == nil {
+		t.Fatalf("expected timeout error")
+	}
+}
+
+func TestWithTimeoutNonClientGetter(t *testing.T) {
+	hg := WithTimeout(5 * time.Millisecond)(blockingGetter{})
+
+	_, err := hg.Get("http://example.com")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWithInMemoryCache(t *testing.T) {
+	g := &countingGetter{}
+	hg := WithInMemoryCache(time.Minute, 10)(g)
+
+	for i := 0; i < 3; i++ {
+		resp, err := hg.Get("http://example.com")
+		if err != nil {
+			t.Fatalf("Get %d failed: %s", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	if g.attempts != 1 {
+		t.Fatalf("expected underlying getter to be called once, got %d", g.attempts)
+	}
+}
+
+func TestWithInMemoryCacheExpiry(t *testing.T) {
+	g := &countingGetter{}
+	hg := WithInMemoryCache(10*time.Millisecond, 10)(g)
+
+	resp, err := hg.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	resp.Body.Close()
+
+	time.Sleep(30 * time.Millisecond)
+
+	resp, err = hg.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Get after expiry failed: %s", err)
+	}
+	resp.Body.Close()
+
+	if g.attempts != 2 {
+		t.Fatalf("expected a refetch after ttl expiry, got %d attempts", g.attempts)
+	}
+}
+
+func TestWithInMemoryCacheContextPreserved(t *testing.T) {
+	hg := WithInMemoryCache(time.Minute, 10)(blockingGetter{})
+	cg, ok := hg.(ContextHTTPGetter)
+	if !ok {
+		t.Fatalf("WithInMemoryCache must preserve ContextHTTPGetter")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := cg.GetContext(ctx, "http://example.com")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWithHostRateLimit(t *testing.T) {
+	g := &countingGetter{}
+	hg := WithHostRateLimit(1000)(g)
+
+	resp, err := hg.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	resp.Body.Close()
+	if g.attempts != 1 {
+		t.Fatalf("expected underlying getter to be called once, got %d", g.attempts)
+	}
+}
+
+func TestWithHostRateLimitRespectsContextCancellation(t *testing.T) {
+	g := &countingGetter{}
+	hg := WithHostRateLimit(0.001)(g)
+	cg := hg.(ContextHTTPGetter)
+
+	// First call consumes the single burst token; the second has to
+	// wait long enough that a short ctx deadline fires first.
+	resp, err := cg.GetContext(context.Background(), "http://example.com")
+	if err != nil {
+		t.Fatalf("first Get failed: %s", err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = cg.GetContext(ctx, "http://example.com")
+	if err == nil {
+		t.Fatalf("expected an error once the rate limit forces a wait past ctx's deadline")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	hg := WithProxy("http://proxy.example.com")(http.DefaultClient)
+
+	c, ok := hg.(*http.Client)
+	if !ok {
+		t.Fatalf("expected *http.Client, got %T", hg)
+	}
+	if c.Transport == nil {
+		t.Fatalf("expected Transport to be set")
+	}
+}
+
+func TestWithProxyNonClientGetter(t *testing.T) {
+	g := &countingGetter{}
+	hg := WithProxy("http://proxy.example.com")(g)
+
+	if hg != HTTPGetter(g) {
+		t.Fatalf("WithProxy should pass through non-*http.Client getters unchanged")
+	}
+}
+
+// TestWithProxyPreservesOuterTransport checks that wrapping a client
+// that already has a middleware-installed Transport (as WithUserAgent
+// does) with WithProxy composes the proxy onto the underlying
+// *http.Transport instead of discarding the outer wrapper, regardless
+// of which middleware was applied first.
+func TestWithProxyPreservesOuterTransport(t *testing.T) {
+	hg := Chain(http.DefaultClient, WithProxy("http://proxy.example.com"), WithUserAgent("bot"))
+	c, ok := hg.(*http.Client)
+	if !ok {
+		t.Fatalf("expected *http.Client, got %T", hg)
+	}
+
+	ua, ok := c.Transport.(*userAgentTransport)
+	if !ok {
+		t.Fatalf("expected outer Transport to still be *userAgentTransport, got %T", c.Transport)
+	}
+
+	tr, ok := ua.next.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected userAgentTransport.next to be *http.Transport, got %T", ua.next)
+	}
+	if tr.Proxy == nil {
+		t.Fatalf("expected the underlying *http.Transport to have a Proxy configured")
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var gotUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{}
+	hg := WithUserAgent("hcproto-test/1.0")(client)
+
+	resp, err := hg.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	resp.Body.Close()
+
+	if gotUA != "hcproto-test/1.0" {
+		t.Fatalf("expected User-Agent %q, got %q", "hcproto-test/1.0", gotUA)
+	}
+}
+
+func TestChainOrdersMiddlewareOutermostFirst(t *testing.T) {
+	var order []string
+	tag := func(name string) GetterMiddleware {
+		return func(next HTTPGetter) HTTPGetter {
+			return getterFunc(func(u string) (*http.Response, error) {
+				order = append(order, name)
+				return next.Get(u)
+			})
+		}
+	}
+
+	base := &countingGetter{}
+	hg := Chain(base, tag("outer"), tag("inner"))
+
+	resp, err := hg.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	resp.Body.Close()
+
+	want := []string{"outer", "inner"}
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] {
+		t.Fatalf("expected call order %v, got %v", want, order)
+	}
+}
@@ -0,0 +1,146 @@
+package hcproto
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultMaxConcurrency bounds how many title fetches ParseContext
+	// runs at once.
+	defaultMaxConcurrency = 8
+	// defaultMaxTitleBytes bounds how much of a page body is read while
+	// looking for its title.
+	defaultMaxTitleBytes = 1 << 20 // 1 MiB
+	// defaultStreamWindow bounds the lookahead ParseStream uses to
+	// recognize a single mention, emotion or link.
+	defaultStreamWindow = 8192
+)
+
+// ContextHTTPGetter is an optional extension of HTTPGetter. Getters
+// that implement it, and *http.Client, have their fetches aborted as
+// soon as the passed context is done; for other getters the caller
+// stops waiting once the context is done, but their Get call keeps
+// running in the background.
+type ContextHTTPGetter interface {
+	GetContext(ctx context.Context, url string) (*http.Response, error)
+}
+
+// ParserOption configures optional Parser behavior. Use with
+// NewParserWithOptions.
+type ParserOption func(*Parser)
+
+// WithMaxConcurrency bounds the number of title fetches ParseContext
+// performs at once. The default is 8.
+func WithMaxConcurrency(n int) ParserOption {
+	return func(p *Parser) {
+		if n > 0 {
+			p.maxConcurrency = n
+		}
+	}
+}
+
+// WithFetchTimeout sets a per-URL timeout applied to every title fetch,
+// on top of whatever deadline the caller's context already carries.
+// Zero, the default, means no additional timeout is applied.
+func WithFetchTimeout(d time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.fetchTimeout = d
+	}
+}
+
+// WithMaxTitleBytes limits how many bytes of a page body are read while
+// looking for its title, so a misbehaving page can't exhaust memory.
+// The default is 1 MiB. A value <= 0 disables the limit.
+func WithMaxTitleBytes(n int64) ParserOption {
+	return func(p *Parser) {
+		p.maxTitleBytes = n
+	}
+}
+
+// WithEmotionCatalog overrides which emotions are recognized by
+// (name) syntax. The default is StaticEmotionCatalog, the fixed
+// built-in list; pass NewFileEmotionCatalog or NewHTTPEmotionCatalog to
+// source the list externally.
+func WithEmotionCatalog(catalog EmotionCatalog) ParserOption {
+	return func(p *Parser) {
+		p.catalog = catalog
+	}
+}
+
+// WithPositions makes Parse, ParseContext and ParseStream populate
+// MsgInfo.MentionHits, EmotionHits and LinkHits with the byte span of
+// each element in the source message. It's off by default so the JSON
+// shape of MsgInfo doesn't change for existing callers.
+func WithPositions() ParserOption {
+	return func(p *Parser) {
+		p.positions = true
+	}
+}
+
+// WithStreamWindow bounds the lookahead ParseStream uses to recognize
+// a single mention, emotion or link. The default is 8192 bytes; a
+// token longer than the window is truncated at the window's edge.
+func WithStreamWindow(n int) ParserOption {
+	return func(p *Parser) {
+		if n > 0 {
+			p.streamWindow = n
+		}
+	}
+}
+
+// NewParserWithOptions is like NewParser but allows tuning the
+// concurrency, timeouts and other limits used by ParseContext.
+func NewParserWithOptions(hg HTTPGetter, opts ...ParserOption) *Parser {
+	p := &Parser{
+		hg:             hg,
+		catalog:        StaticEmotionCatalog{},
+		maxConcurrency: defaultMaxConcurrency,
+		maxTitleBytes:  defaultMaxTitleBytes,
+		streamWindow:   defaultStreamWindow,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// getContext performs a GET against url using hg, preferring
+// GetContext when hg implements ContextHTTPGetter so the request is
+// actually aborted when ctx is done. An *http.Client is sent a request
+// built with http.NewRequestWithContext, which is aborted the same way.
+// Other HTTPGetter implementations are raced against ctx instead: the
+// caller stops waiting once ctx is
+// done, but the underlying Get call may still run to completion in the
+// background.
+func getContext(ctx context.Context, hg HTTPGetter, url string) (*http.Response, error) {
+	if cg, ok := hg.(ContextHTTPGetter); ok {
+		return cg.GetContext(ctx, url)
+	}
+	if c, ok := hg.(*http.Client); ok {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		return c.Do(req)
+	}
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		resp, err := hg.Get(url)
+		ch <- result{resp, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.resp, r.err
+	}
+}
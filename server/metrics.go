@@ -0,0 +1,26 @@
+package server
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	parseDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "hcproto_parse_duration_seconds",
+		Help: "Time spent in Parser.ParseContext, including title fetches.",
+	})
+
+	linkFetchErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hcproto_link_fetch_errors_total",
+		Help: "Number of link title fetches that returned an error.",
+	})
+
+	titleCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hcproto_title_cache_hits_total",
+		Help: "Number of title fetches served from the in-memory cache.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(parseDuration, linkFetchErrors, titleCacheHits)
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// perClientLimiter throttles requests per client IP to rps requests
+// per second, bursting up to burst.
+type perClientLimiter struct {
+	rps   float64
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newPerClientLimiter(rps float64, burst int) *perClientLimiter {
+	return &perClientLimiter{
+		rps:      rps,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+func (l *perClientLimiter) allow(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	l.mu.Lock()
+	lim, ok := l.limiters[host]
+	if !ok {
+		lim = rate.NewLimiter(rate.Limit(l.rps), l.burst)
+		l.limiters[host] = lim
+	}
+	l.mu.Unlock()
+
+	return lim.Allow()
+}
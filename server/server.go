@@ -0,0 +1,264 @@
+// Package server exposes an hcproto.Parser as an HTTP and gRPC
+// service, so chat backends can call it without embedding Go code.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vchimishuk/hcproto"
+	"github.com/vchimishuk/hcproto/server/pb"
+	"google.golang.org/grpc"
+)
+
+// defaultMaxRequestBytes bounds the size of a POST /parse body.
+const defaultMaxRequestBytes = 1 << 20 // 1 MiB
+
+// Options configures Server.
+type Options struct {
+	// HTTPAddr is the address the REST API listens on, e.g. ":8080".
+	// Empty disables the HTTP listener.
+	HTTPAddr string
+	// GRPCAddr is the address ParseService listens on, e.g. ":9090".
+	// Empty disables the gRPC listener.
+	GRPCAddr string
+	// MaxRequestBytes bounds the size of a POST /parse body. The
+	// default is 1 MiB.
+	MaxRequestBytes int64
+	// RateLimit bounds how many requests per second a single client
+	// (by remote IP) may make. Zero disables rate limiting.
+	RateLimit float64
+	// RateLimitBurst is the burst size for RateLimit. The default is 1.
+	RateLimitBurst int
+}
+
+// Server wraps an hcproto.Parser behind HTTP and gRPC APIs.
+type Server struct {
+	parser *hcproto.Parser
+	opts   Options
+
+	httpSrv *http.Server
+	grpcSrv *grpc.Server
+}
+
+// New returns a Server that parses messages with parser.
+func New(parser *hcproto.Parser, opts Options) *Server {
+	if opts.MaxRequestBytes <= 0 {
+		opts.MaxRequestBytes = defaultMaxRequestBytes
+	}
+	if opts.RateLimitBurst <= 0 {
+		opts.RateLimitBurst = 1
+	}
+
+	return &Server{parser: parser, opts: opts}
+}
+
+// ListenAndServe starts the configured listeners and blocks until ctx
+// is done, then gracefully shuts them down.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+
+	if s.opts.HTTPAddr != "" {
+		s.httpSrv = &http.Server{
+			Addr:    s.opts.HTTPAddr,
+			Handler: s.httpHandler(),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf(`{"msg":"http server listening","addr":%q}`, s.opts.HTTPAddr)
+			if err := s.httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errs <- err
+			}
+		}()
+	}
+
+	if s.opts.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", s.opts.GRPCAddr)
+		if err != nil {
+			s.Shutdown()
+			wg.Wait()
+			return err
+		}
+		s.grpcSrv = grpc.NewServer()
+		pb.RegisterParseServiceServer(s.grpcSrv, &grpcServer{parser: s.parser})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf(`{"msg":"grpc server listening","addr":%q}`, s.opts.GRPCAddr)
+			if err := s.grpcSrv.Serve(lis); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.Shutdown()
+	}()
+
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Shutdown gracefully stops the HTTP and gRPC listeners.
+func (s *Server) Shutdown() {
+	if s.httpSrv != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.httpSrv.Shutdown(ctx); err != nil {
+			log.Printf(`{"msg":"http server shutdown error","error":%q}`, err.Error())
+		}
+	}
+	if s.grpcSrv != nil {
+		s.grpcSrv.GracefulStop()
+	}
+}
+
+func (s *Server) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/parse", s.rateLimited(http.HandlerFunc(s.handleParse)))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return mux
+}
+
+func (s *Server) rateLimited(next http.Handler) http.Handler {
+	if s.opts.RateLimit <= 0 {
+		return next
+	}
+
+	limiter := newPerClientLimiter(s.opts.RateLimit, s.opts.RateLimitBurst)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !limiter.allow(r) {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type parseRequest struct {
+	Message string `json:"message"`
+}
+
+// getterContextFunc adapts a pair of functions to an hcproto.HTTPGetter
+// that also implements hcproto.ContextHTTPGetter.
+type getterContextFunc struct {
+	get        func(url string) (*http.Response, error)
+	getContext func(ctx context.Context, url string) (*http.Response, error)
+}
+
+func (f getterContextFunc) Get(url string) (*http.Response, error) {
+	return f.get(url)
+}
+
+func (f getterContextFunc) GetContext(ctx context.Context, url string) (*http.Response, error) {
+	return f.getContext(ctx, url)
+}
+
+// InstrumentedGetter wraps next so hcproto_link_fetch_errors_total and
+// hcproto_title_cache_hits_total are updated as URLs are fetched. A
+// hit is recorded for any URL this process has already fetched once,
+// which approximates the behavior of an hcproto.WithInMemoryCache
+// layered underneath. It preserves next's ContextHTTPGetter
+// capability, if any, so wrapping it doesn't defeat cancellation.
+func InstrumentedGetter(next hcproto.HTTPGetter) hcproto.HTTPGetter {
+	seen := &sync.Map{}
+
+	return getterContextFunc{
+		get: func(u string) (*http.Response, error) {
+			return instrumentedGet(context.Background(), next, seen, u)
+		},
+		getContext: func(ctx context.Context, u string) (*http.Response, error) {
+			return instrumentedGet(ctx, next, seen, u)
+		},
+	}
+}
+
+func instrumentedGet(ctx context.Context, next hcproto.HTTPGetter, seen *sync.Map, u string) (*http.Response, error) {
+	if _, ok := seen.LoadOrStore(u, struct{}{}); ok {
+		titleCacheHits.Inc()
+	}
+
+	var resp *http.Response
+	var err error
+	if cg, ok := next.(hcproto.ContextHTTPGetter); ok {
+		resp, err = cg.GetContext(ctx, u)
+	} else {
+		resp, err = next.Get(u)
+	}
+	if err != nil {
+		linkFetchErrors.Inc()
+	}
+
+	return resp, err
+}
+
+func (s *Server) handleParse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxRequestBytes)
+
+	var req parseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	mi, err := s.parser.ParseContext(r.Context(), req.Message)
+	parseDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		log.Printf(`{"msg":"parse failed","error":%q}`, err.Error())
+		http.Error(w, "parse failed", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(mi); err != nil {
+		log.Printf(`{"msg":"encode response failed","error":%q}`, err.Error())
+	}
+}
+
+// grpcServer adapts hcproto.Parser to pb.ParseServiceServer.
+type grpcServer struct {
+	pb.UnimplementedParseServiceServer
+	parser *hcproto.Parser
+}
+
+func (g *grpcServer) Parse(ctx context.Context, req *pb.ParseRequest) (*pb.ParseResponse, error) {
+	start := time.Now()
+	mi, err := g.parser.ParseContext(ctx, req.GetMessage())
+	parseDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]*pb.Link, len(mi.Links))
+	for i, l := range mi.Links {
+		links[i] = &pb.Link{Url: l.URL, Title: l.Title}
+	}
+
+	return &pb.ParseResponse{
+		Mentions: mi.Mentions,
+		Emotions: mi.Emotions,
+		Links:    links,
+	}, nil
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vchimishuk/hcproto"
+)
+
+// stubGetter never needs to fetch anything for these tests; the
+// messages below carry no links.
+type stubGetter struct{}
+
+func (stubGetter) Get(url string) (*http.Response, error) {
+	return nil, errors.New("not reachable in tests")
+}
+
+func reserveAddr(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("reserve addr: %s", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	return addr
+}
+
+func TestListenAndServeHTTPParse(t *testing.T) {
+	addr := reserveAddr(t)
+	parser := hcproto.NewParser(stubGetter{})
+	srv := New(parser, Options{HTTPAddr: addr})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe(ctx) }()
+
+	waitForHTTP(t, addr)
+
+	resp, err := http.Post("http://"+addr+"/parse", "application/json",
+		strings.NewReader(`{"message":"hi @user (heart)"}`))
+	if err != nil {
+		t.Fatalf("POST /parse failed: %s", err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(string(body), `"user"`) || !strings.Contains(string(body), `"heart"`) {
+		t.Fatalf("unexpected response body: %s", body)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("ListenAndServe returned error after clean shutdown: %s", err)
+	}
+}
+
+func TestListenAndServeRateLimit(t *testing.T) {
+	addr := reserveAddr(t)
+	parser := hcproto.NewParser(stubGetter{})
+	srv := New(parser, Options{HTTPAddr: addr, RateLimit: 0.001, RateLimitBurst: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.ListenAndServe(ctx)
+
+	waitForHTTP(t, addr)
+
+	post := func() int {
+		resp, err := http.Post("http://"+addr+"/parse", "application/json",
+			strings.NewReader(`{"message":"hi"}`))
+		if err != nil {
+			t.Fatalf("POST /parse failed: %s", err)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := post(); got != http.StatusOK {
+		t.Fatalf("expected first request to succeed, got %d", got)
+	}
+	if got := post(); got != http.StatusTooManyRequests {
+		t.Fatalf("expected second request to be rate limited, got %d", got)
+	}
+}
+
+// TestListenAndServeGRPCBindFailureShutsDownHTTP checks that when the
+// gRPC listener fails to bind, ListenAndServe doesn't leave the HTTP
+// listener it already started running in the background.
+func TestListenAndServeGRPCBindFailureShutsDownHTTP(t *testing.T) {
+	httpAddr := reserveAddr(t)
+
+	conflict, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("reserve conflicting addr: %s", err)
+	}
+	defer conflict.Close()
+
+	parser := hcproto.NewParser(stubGetter{})
+	srv := New(parser, Options{HTTPAddr: httpAddr, GRPCAddr: conflict.Addr().String()})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe(context.Background()) }()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatalf("expected a bind error from the occupied gRPC address")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("ListenAndServe did not return after a gRPC bind failure; the HTTP listener was likely left running")
+	}
+
+	if _, err := http.Get("http://" + httpAddr + "/parse"); err == nil {
+		t.Fatalf("expected the HTTP listener to have been shut down after the gRPC bind failure")
+	}
+}
+
+func waitForHTTP(t *testing.T, addr string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("HTTP server at %s never started accepting connections", addr)
+}
@@ -0,0 +1,108 @@
+package hcproto
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Span is a half-open byte range [Start, End) within the source
+// message or stream.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// MentionHit is a mentioned username together with where it occurred.
+type MentionHit struct {
+	Name string `json:"name"`
+	Span Span   `json:"span"`
+}
+
+// EmotionHit is a mentioned emotion together with where it occurred.
+type EmotionHit struct {
+	Name string `json:"name"`
+	Span Span   `json:"span"`
+}
+
+// LinkHit is a Link together with where its URL occurred.
+type LinkHit struct {
+	Link
+	Span Span `json:"span"`
+}
+
+// ParseStream is like Parse but reads msg from r instead of holding
+// the whole message in memory at once, so very long transcripts or log
+// files can be parsed without allocating the whole input as a string.
+// It keeps a bounded lookahead window (see WithStreamWindow) rather
+// than buffering all of r.
+func (p *Parser) ParseStream(r io.Reader) (*MsgInfo, error) {
+	sr, err := p.scanStream(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.buildMsgInfo(context.Background(), sr), nil
+}
+
+// scanStream is the streaming counterpart of scan: it never holds more
+// than p.streamWindow bytes of r in memory at once.
+func (p *Parser) scanStream(r io.Reader) (scanResult, error) {
+	var sr scanResult
+	br := bufio.NewReaderSize(r, p.streamWindow)
+	var offset int
+	var prevLetterOrDigit bool
+
+	for {
+		peek, _ := br.Peek(p.streamWindow)
+		if len(peek) == 0 {
+			break
+		}
+
+		s := string(peek)
+		r0, size := utf8.DecodeRuneInString(s)
+		if r0 == utf8.RuneError && size == 1 {
+			return scanResult{}, fmt.Errorf("invalid rune at position %d", offset)
+		}
+
+		consumed := size
+		switch r0 {
+		case '(':
+			e, n := emotion(s, p.catalog)
+			if e != "" {
+				consumed = n
+				sr.emotions = append(sr.emotions, e)
+				sr.emotionHits = append(sr.emotionHits, EmotionHit{Name: e, Span: Span{Start: offset, End: offset + n}})
+			}
+		case '@':
+			m, n := mention(s)
+			if m != "" {
+				consumed = n
+				sr.mentions = append(sr.mentions, m)
+				sr.mentionHits = append(sr.mentionHits, MentionHit{Name: m, Span: Span{Start: offset, End: offset + n}})
+			}
+		case 'H', 'h':
+			if !prevLetterOrDigit {
+				u, n := link(s)
+				if u != "" {
+					consumed = n
+					sr.urls = append(sr.urls, u)
+					sr.urlSpans = append(sr.urlSpans, Span{Start: offset, End: offset + n})
+				}
+			}
+		}
+
+		last, _ := utf8.DecodeLastRuneInString(s[:consumed])
+		prevLetterOrDigit = unicode.IsLetter(last) || unicode.IsDigit(last)
+
+		if _, err := br.Discard(consumed); err != nil {
+			return scanResult{}, err
+		}
+		offset += consumed
+	}
+
+	return sr, nil
+}
@@ -0,0 +1,101 @@
+package hcproto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStreamSpans(t *testing.T) {
+	fhg := fakeHTTPGetter(map[string]string{
+		"http://golang.org": golangOrgPage,
+	})
+	parser := NewParserWithOptions(fhg, WithPositions())
+
+	msg := "Hi, @atlassian(atlassian)! Here is a link:http://golang.org (fry)"
+	mi, err := parser.ParseStream(strings.NewReader(msg))
+	if err != nil {
+		t.Fatalf("ParseStream failed: %s", err)
+	}
+
+	if len(mi.MentionHits) != 1 || mi.MentionHits[0].Name != "atlassian" {
+		t.Fatalf("unexpected MentionHits: %#v", mi.MentionHits)
+	}
+	if got, want := msg[mi.MentionHits[0].Span.Start:mi.MentionHits[0].Span.End], "@atlassian"; got != want {
+		t.Fatalf("mention span covers %q, want %q", got, want)
+	}
+
+	if len(mi.EmotionHits) != 2 {
+		t.Fatalf("unexpected EmotionHits: %#v", mi.EmotionHits)
+	}
+	if got, want := msg[mi.EmotionHits[0].Span.Start:mi.EmotionHits[0].Span.End], "(atlassian)"; got != want {
+		t.Fatalf("first emotion span covers %q, want %q", got, want)
+	}
+	if got, want := msg[mi.EmotionHits[1].Span.Start:mi.EmotionHits[1].Span.End], "(fry)"; got != want {
+		t.Fatalf("second emotion span covers %q, want %q", got, want)
+	}
+
+	if len(mi.LinkHits) != 1 || mi.LinkHits[0].URL != "http://golang.org" {
+		t.Fatalf("unexpected LinkHits: %#v", mi.LinkHits)
+	}
+	if got, want := msg[mi.LinkHits[0].Span.Start:mi.LinkHits[0].Span.End], "http://golang.org"; got != want {
+		t.Fatalf("link span covers %q, want %q", got, want)
+	}
+}
+
+// TestParseStreamMatchesParseContext checks that ParseStream, reading
+// incrementally, finds the same mentions, emotions and links as Parse
+// does over the whole message at once.
+func TestParseStreamMatchesParseContext(t *testing.T) {
+	fhg := fakeHTTPGetter(map[string]string{
+		"http://golang.org":     golangOrgPage,
+		"https://atlassian.com": atlassianComPage,
+	})
+	msg := "Golang homepage: http://golang.org\nAtlassian: https://atlassian.com @user (atlassian)"
+	parser := NewParser(fhg)
+
+	want, err := parser.Parse(msg)
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+
+	got, err := parser.ParseStream(strings.NewReader(msg))
+	if err != nil {
+		t.Fatalf("ParseStream failed: %s", err)
+	}
+
+	if !msgInfoEqual(want, got) {
+		t.Fatalf("ParseStream result differs from Parse: expected %#v found %#v", want, got)
+	}
+}
+
+// TestParseStreamTruncatesAtWindowEdge documents that a token (here, a
+// URL) longer than WithStreamWindow's lookahead is cut off at the
+// window's edge, since scanStream never buffers more than streamWindow
+// bytes of lookahead at once.
+func TestParseStreamTruncatesAtWindowEdge(t *testing.T) {
+	fhg := fakeHTTPGetter(map[string]string{})
+	parser := NewParserWithOptions(fhg, WithStreamWindow(16))
+
+	url := "http://example.com/" + strings.Repeat("a", 64)
+	mi, err := parser.ParseStream(strings.NewReader(url))
+	if err != nil {
+		t.Fatalf("ParseStream failed: %s", err)
+	}
+
+	if len(mi.Links) != 1 {
+		t.Fatalf("expected exactly one (truncated) link, got %#v", mi.Links)
+	}
+	if got := mi.Links[0].URL; len(got) != 16 || !strings.HasPrefix(url, got) {
+		t.Fatalf("expected URL truncated to the 16-byte window, got %q", got)
+	}
+}
+
+func TestParseStreamInvalidUTF8(t *testing.T) {
+	fhg := fakeHTTPGetter(map[string]string{})
+	parser := NewParser(fhg)
+
+	_, err := parser.ParseStream(strings.NewReader("hello \xff world"))
+	if err == nil {
+		t.Fatalf("expected an error for invalid UTF-8 input")
+	}
+}
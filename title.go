@@ -0,0 +1,95 @@
+package hcproto
+
+import (
+	stdhtml "html"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// titleExtractor pulls a human-readable title out of an HTML response.
+// It transcodes non-UTF-8 pages using the declared or sniffed charset,
+// decodes HTML entities in the result, and falls back to OpenGraph,
+// Twitter Card or the first <h1> when the page has no <title>.
+type titleExtractor struct {
+	// maxBytes bounds how much of the body is read looking for a
+	// title, so a large or slow-to-send page can't exhaust memory.
+	// Zero or negative means no limit.
+	maxBytes int64
+}
+
+func newTitleExtractor(maxBytes int64) *titleExtractor {
+	return &titleExtractor{maxBytes: maxBytes}
+}
+
+func (e *titleExtractor) extract(resp *http.Response) (string, error) {
+	var body io.Reader = resp.Body
+	if e.maxBytes > 0 {
+		body = io.LimitReader(body, e.maxBytes)
+	}
+
+	r, err := charset.NewReader(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+
+	var ogTitle, twitterTitle, h1 string
+	t := html.NewTokenizer(r)
+
+	for {
+		switch t.Next() {
+		case html.ErrorToken:
+			return stdhtml.UnescapeString(firstNonEmpty(ogTitle, twitterTitle, h1)), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := t.TagName()
+
+			switch string(name) {
+			case "title":
+				if t.Next() == html.TextToken {
+					return stdhtml.UnescapeString(string(t.Text())), nil
+				}
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				attrs := metaAttrs(t)
+				switch attrs["property"] {
+				case "og:title":
+					ogTitle = attrs["content"]
+				}
+				switch attrs["name"] {
+				case "twitter:title":
+					twitterTitle = attrs["content"]
+				}
+			case "h1":
+				if h1 == "" && t.Next() == html.TextToken {
+					h1 = string(t.Text())
+				}
+			}
+		}
+	}
+}
+
+func metaAttrs(t *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, val, more := t.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(val)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,117 @@
+package hcproto
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func extractTitle(t *testing.T, body string, headers http.Header) string {
+	t.Helper()
+
+	if headers == nil {
+		headers = http.Header{}
+	}
+	resp := &http.Response{
+		Header: headers,
+		Body:   ioutil.NopCloser(strings.NewReader(body)),
+	}
+
+	title, err := newTitleExtractor(0).extract(resp)
+	if err != nil {
+		t.Fatalf("extract failed: %s", err)
+	}
+
+	return title
+}
+
+func TestTitleExtractorTitleTag(t *testing.T) {
+	got := extractTitle(t, golangOrgPage, nil)
+	if got != "The Go Programming Language" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+}
+
+func TestTitleExtractorEntityDecoding(t *testing.T) {
+	body := `<html><head><title>Q&amp;A &mdash; Rock &amp; Roll</title></head></html>`
+	got := extractTitle(t, body, nil)
+	want := "Q&A — Rock & Roll"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTitleExtractorOpenGraphFallback(t *testing.T) {
+	body := `<html><head>
+		<meta property="og:title" content="OG Title">
+	</head><body></body></html>`
+	got := extractTitle(t, body, nil)
+	if got != "OG Title" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+}
+
+func TestTitleExtractorTwitterFallback(t *testing.T) {
+	body := `<html><head>
+		<meta name="twitter:title" content="Twitter Title">
+	</head><body></body></html>`
+	got := extractTitle(t, body, nil)
+	if got != "Twitter Title" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+}
+
+func TestTitleExtractorH1Fallback(t *testing.T) {
+	body := `<html><body><h1>Heading Title</h1></body></html>`
+	got := extractTitle(t, body, nil)
+	if got != "Heading Title" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+}
+
+func TestTitleExtractorFallbackPriority(t *testing.T) {
+	body := `<html><head>
+		<meta name="twitter:title" content="Twitter Title">
+		<meta property="og:title" content="OG Title">
+	</head><body><h1>Heading Title</h1></body></html>`
+	got := extractTitle(t, body, nil)
+	if got != "OG Title" {
+		t.Fatalf("expected og:title to win over twitter:title and h1, got %q", got)
+	}
+}
+
+func TestTitleExtractorNonUTF8Charset(t *testing.T) {
+	const want = "Заголовок страницы"
+	encoded, err := charmap.Windows1251.NewEncoder().String(
+		`<html><head><title>` + want + `</title></head></html>`)
+	if err != nil {
+		t.Fatalf("encode fixture: %s", err)
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "text/html; charset=windows-1251")
+	got := extractTitle(t, encoded, headers)
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTitleExtractorMaxBytes(t *testing.T) {
+	padding := strings.Repeat(" ", 1<<20)
+	body := "<html><head>" + padding + "<title>Too Far</title></head></html>"
+
+	resp := &http.Response{
+		Header: http.Header{},
+		Body:   ioutil.NopCloser(strings.NewReader(body)),
+	}
+	got, err := newTitleExtractor(64).extract(resp)
+	if err != nil {
+		t.Fatalf("extract failed: %s", err)
+	}
+	if got != "" {
+		t.Fatalf("expected no title within the byte limit, got %q", got)
+	}
+}